@@ -0,0 +1,115 @@
+package jot
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ClaimsBuilder lets a caller add (or override) claims on an access token before it's
+// signed, without forking jot to carry roles, tenant IDs, scopes, or MFA metadata that
+// the User type doesn't know about. Whatever it returns is merged over the default
+// claims GenerateTokenPair sets.
+type ClaimsBuilder func(user *User) (jwt.MapClaims, error)
+
+// Claims describes the data carried in a jot access token. It embeds the standard
+// registered claims (iss, sub, aud, exp, iat, jti, ...), plus the optional fields a
+// ClaimsBuilder commonly populates. Anything else a ClaimsBuilder adds that isn't one of
+// these fields round-trips through Extra.
+type Claims struct {
+	// Name is the subject's display name, e.g. "Jane Doe".
+	Name string `json:"name,omitempty"`
+	// Scopes lists the OAuth-style permissions granted to this token.
+	Scopes []string `json:"scopes,omitempty"`
+	// Scope is the RFC 8693 space-separated scope claim, as set by GenerateTokenPair and
+	// GenerateScopedToken.
+	Scope string `json:"scope,omitempty"`
+	// Roles lists the subject's roles, e.g. "admin".
+	Roles []string `json:"roles,omitempty"`
+	// AMR lists the authentication methods used to establish this session, per RFC 8176.
+	AMR []string `json:"amr,omitempty"`
+	// AAL is the authenticator assurance level reached during authentication, e.g. "aal2"
+	// once a multi-factor challenge has been completed.
+	AAL string `json:"aal,omitempty"`
+	// SessionID identifies the login session this token belongs to.
+	SessionID string `json:"sid,omitempty"`
+	// Extra carries any claim a ClaimsBuilder adds that isn't one of the fields above.
+	Extra map[string]any `json:"-"`
+
+	jwt.RegisteredClaims
+}
+
+// HasScope reports whether these claims grant scope, whether it arrived via the plural
+// Scopes claim or the RFC 8693 space-separated Scope claim.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	for _, s := range strings.Fields(c.Scope) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether these claims include role.
+func (c *Claims) HasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// claimsKnownKeys lists every JSON key that Claims and jwt.RegisteredClaims declare a
+// field for, plus "typ", which newAccessToken stamps on every token but which Claims
+// has no field for, so UnmarshalJSON can tell those apart from Extra.
+var claimsKnownKeys = map[string]bool{
+	"name":  true,
+	"scope": true, "scopes": true,
+	"roles": true,
+	"amr":   true,
+	"aal":   true,
+	"sid":   true,
+	"typ":   true,
+	"iss":   true, "sub": true, "aud": true,
+	"exp": true, "nbf": true, "iat": true, "jti": true,
+}
+
+// UnmarshalJSON populates the declared fields as usual, then collects whatever is left
+// over into Extra, so a ClaimsBuilder's custom claims survive the round trip through
+// GetTokenFromHeaderAndVerify even though Claims doesn't have a field for them.
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	type alias Claims
+	var a alias
+	if err := json.Unmarshal(data, &a); err != nil {
+		return err
+	}
+	*c = Claims(a)
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+
+	extra := make(map[string]interface{})
+	for k, raw := range all {
+		if claimsKnownKeys[k] {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return err
+		}
+		extra[k] = v
+	}
+	if len(extra) > 0 {
+		c.Extra = extra
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package jot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func TestAuthClaimsBuilderSurvivesVerification(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	builderApp := app
+	builderApp.ClaimsBuilder = func(user *User) (jwt.MapClaims, error) {
+		return jwt.MapClaims{
+			"roles":      []string{"admin", "billing"},
+			"sid":        "session-123",
+			"amr":        []string{"pwd", "otp"},
+			"aal":        "aal2",
+			"department": "engineering",
+		}, nil
+	}
+
+	tokens, err := builderApp.GenerateTokenPair(&testUser)
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokens.Token))
+	rr := httptest.NewRecorder()
+
+	_, claims, err := builderApp.GetTokenFromHeaderAndVerify(rr, req)
+	if err != nil {
+		t.Fatalf("did not expect error verifying token, got %v", err)
+	}
+
+	if !claims.HasRole("admin") || !claims.HasRole("billing") {
+		t.Errorf("expected roles from ClaimsBuilder to survive verification, got %v", claims.Roles)
+	}
+	if claims.SessionID != "session-123" {
+		t.Errorf("expected sid %q, got %q", "session-123", claims.SessionID)
+	}
+	if claims.AAL != "aal2" {
+		t.Errorf("expected aal %q, got %q", "aal2", claims.AAL)
+	}
+	if len(claims.AMR) != 2 || claims.AMR[0] != "pwd" || claims.AMR[1] != "otp" {
+		t.Errorf("expected amr [pwd otp], got %v", claims.AMR)
+	}
+	if claims.Extra["department"] != "engineering" {
+		t.Errorf("expected unknown claim %q to round-trip through Extra, got %v", "department", claims.Extra)
+	}
+}
+
+func TestAuthClaimsBuilderSurvivesMiddleware(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	builderApp := app
+	builderApp.ClaimsBuilder = func(user *User) (jwt.MapClaims, error) {
+		return jwt.MapClaims{"roles": []string{"admin"}}, nil
+	}
+
+	tokens, err := builderApp.GenerateTokenPair(&testUser)
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	var gotClaims *Claims
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		if !ok {
+			t.Error("expected claims in context, but none found")
+		}
+		gotClaims = claims
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokens.Token))
+	rr := httptest.NewRecorder()
+
+	builderApp.Middleware(next).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+	}
+	if gotClaims == nil || !gotClaims.HasRole("admin") {
+		t.Errorf("expected role from ClaimsBuilder to survive Middleware, got %v", gotClaims)
+	}
+}
+
+func TestClaimsHasRole(t *testing.T) {
+	claims := Claims{Roles: []string{"admin", "editor"}}
+
+	if !claims.HasRole("admin") {
+		t.Error("expected HasRole to find a role the claims carry")
+	}
+	if claims.HasRole("superadmin") {
+		t.Error("expected HasRole to reject a role the claims do not carry")
+	}
+}
+
+func TestClaimsUnmarshalJSONRoundTripsUnknownClaims(t *testing.T) {
+	data := []byte(`{"iss":"example.com","sub":"1","name":"Jane Doe","tenant_id":"acme","plan":"pro"}`)
+
+	var claims Claims
+	if err := claims.UnmarshalJSON(data); err != nil {
+		t.Fatalf("did not expect error unmarshalling claims, got %v", err)
+	}
+
+	if claims.Name != "Jane Doe" {
+		t.Errorf("expected declared field name to unmarshal normally, got %q", claims.Name)
+	}
+	if claims.Extra["tenant_id"] != "acme" {
+		t.Errorf("expected unknown claim %q to land in Extra, got %v", "tenant_id", claims.Extra)
+	}
+	if claims.Extra["plan"] != "pro" {
+		t.Errorf("expected unknown claim %q to land in Extra, got %v", "plan", claims.Extra)
+	}
+	if _, ok := claims.Extra["name"]; ok {
+		t.Error("expected declared field name not to also appear in Extra")
+	}
+}
+
+func TestClaimsUnmarshalJSONNoExtraWhenNothingUnknown(t *testing.T) {
+	data := []byte(`{"iss":"example.com","sub":"1","name":"Jane Doe"}`)
+
+	var claims Claims
+	if err := claims.UnmarshalJSON(data); err != nil {
+		t.Fatalf("did not expect error unmarshalling claims, got %v", err)
+	}
+
+	if claims.Extra != nil {
+		t.Errorf("expected nil Extra when every claim is declared, got %v", claims.Extra)
+	}
+}
+
+func TestAuthGenerateTokenPairNoExtraWithoutClaimsBuilder(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	// newAccessToken always stamps a "typ" claim, even with no ClaimsBuilder configured;
+	// claimsKnownKeys must know about it so it doesn't leak into Extra.
+	tokens, err := app.GenerateTokenPair(&testUser)
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	claims, err := app.verifyToken(tokens.Token)
+	if err != nil {
+		t.Fatalf("did not expect error verifying token, got %v", err)
+	}
+
+	if claims.Extra != nil {
+		t.Errorf("expected nil Extra with no ClaimsBuilder configured, got %v", claims.Extra)
+	}
+}
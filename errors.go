@@ -0,0 +1,40 @@
+package jot
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Sentinel errors returned by verification. Check against these with errors.Is instead
+// of matching on err.Error(), which isn't guaranteed to stay stable across golang-jwt
+// versions.
+var (
+	// ErrNoAuthHeader is returned when a request carries no usable access token at all
+	// (no Authorization header, and, for Middleware, no access token cookie either).
+	ErrNoAuthHeader = errors.New("jot: no auth header")
+
+	// ErrExpiredToken is returned when a token's exp claim is in the past.
+	ErrExpiredToken = errors.New("jot: token is expired")
+
+	// ErrInvalidIssuer is returned when a token's iss claim doesn't match Auth.Issuer.
+	ErrInvalidIssuer = errors.New("jot: token has an invalid issuer")
+
+	// ErrInvalidAudience is returned when a token's aud claim doesn't include
+	// Auth.Audience.
+	ErrInvalidAudience = errors.New("jot: token has an invalid audience")
+
+	// ErrTokenNotFresh is returned when Auth.IATLeeway is set and a token's iat claim
+	// is further from the server clock than that leeway allows.
+	ErrTokenNotFresh = errors.New("jot: token is not fresh")
+)
+
+// isExpiredError reports whether err is (or wraps) a golang-jwt validation error flagged
+// as an expired-token error.
+func isExpiredError(err error) bool {
+	var verr *jwt.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	return verr.Errors&jwt.ValidationErrorExpired != 0
+}
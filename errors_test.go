@@ -0,0 +1,72 @@
+package jot
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestAuthVerifyTokenSentinelErrors asserts that every sentinel error in errors.go is
+// something a caller can reliably detect with errors.Is, which is the whole point of
+// having them rather than matching on err.Error().
+func TestAuthVerifyTokenSentinelErrors(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	tokens, err := app.GenerateTokenPair(&testUser)
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	t.Run("ErrExpiredToken", func(t *testing.T) {
+		_, err := app.verifyToken(expiredToken)
+		if !errors.Is(err, ErrExpiredToken) {
+			t.Errorf("expected ErrExpiredToken, got %v", err)
+		}
+	})
+
+	t.Run("ErrInvalidIssuer", func(t *testing.T) {
+		issuerApp := app
+		issuerApp.Issuer = "other.org"
+
+		_, err := issuerApp.verifyToken(tokens.Token)
+		if !errors.Is(err, ErrInvalidIssuer) {
+			t.Errorf("expected ErrInvalidIssuer, got %v", err)
+		}
+	})
+
+	t.Run("ErrInvalidAudience", func(t *testing.T) {
+		audienceApp := app
+		audienceApp.Audience = "other.org"
+
+		_, err := audienceApp.verifyToken(tokens.Token)
+		if !errors.Is(err, ErrInvalidAudience) {
+			t.Errorf("expected ErrInvalidAudience, got %v", err)
+		}
+	})
+
+	t.Run("ErrTokenNotFresh", func(t *testing.T) {
+		leewayApp := app
+		leewayApp.IATLeeway = time.Minute
+
+		_, err := leewayApp.verifyToken(signTestTokenWithIAT(t, time.Now().Add(-time.Hour)))
+		if !errors.Is(err, ErrTokenNotFresh) {
+			t.Errorf("expected ErrTokenNotFresh, got %v", err)
+		}
+	})
+
+	t.Run("ErrNoAuthHeader", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/", nil)
+		rr := httptest.NewRecorder()
+
+		_, _, err := app.GetTokenFromHeaderAndVerify(rr, req)
+		if !errors.Is(err, ErrNoAuthHeader) {
+			t.Errorf("expected ErrNoAuthHeader, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package jot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// signTestTokenWithIAT builds and signs a token using app's issuer, audience, and
+// secret, with iat set explicitly (or omitted if zero), so IATLeeway can be exercised
+// without waiting on real time to pass.
+func signTestTokenWithIAT(t *testing.T, iat time.Time) string {
+	t.Helper()
+
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["sub"] = "1"
+	claims["aud"] = app.Audience
+	claims["iss"] = app.Issuer
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	if !iat.IsZero() {
+		claims["iat"] = iat.Unix()
+	}
+
+	signed, err := token.SignedString([]byte(app.Secret))
+	if err != nil {
+		t.Fatalf("did not expect error signing test token, got %v", err)
+	}
+	return signed
+}
+
+func TestAuthVerifyTokenIATLeeway(t *testing.T) {
+	leewayApp := app
+	leewayApp.IATLeeway = time.Minute
+
+	tests := []struct {
+		name string
+		iat  time.Time
+		// wantErrIs is the sentinel err must satisfy errors.Is against; nil means no
+		// error is expected at all.
+		wantErrIs error
+	}{
+		{"fresh token within leeway", time.Now(), nil},
+		{"token too old", time.Now().Add(-time.Hour), ErrTokenNotFresh},
+		{"token with no iat claim at all", time.Time{}, ErrTokenNotFresh},
+	}
+
+	for _, e := range tests {
+		signed := signTestTokenWithIAT(t, e.iat)
+
+		_, err := leewayApp.verifyToken(signed)
+		if e.wantErrIs == nil {
+			if err != nil {
+				t.Errorf("%s: did not expect error, got %v", e.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, e.wantErrIs) {
+			t.Errorf("%s: expected %v, got %v", e.name, e.wantErrIs, err)
+		}
+	}
+
+	// A token whose iat is ahead of the server clock - clock skew beyond what IATLeeway
+	// allows - must also be rejected, even though golang-jwt itself (rather than our
+	// IATLeeway check) is what catches it.
+	future := signTestTokenWithIAT(t, time.Now().Add(time.Hour))
+	if _, err := leewayApp.verifyToken(future); err == nil {
+		t.Error("expected error for a token issued in the future, but got none")
+	}
+}
+
+func TestAuthVerifyTokenIATLeewayDisabled(t *testing.T) {
+	// With IATLeeway left at its zero value, a token with a stale iat must still verify,
+	// since the freshness check is opt-in.
+	signed := signTestTokenWithIAT(t, time.Now().Add(-time.Hour))
+
+	if _, err := app.verifyToken(signed); err != nil {
+		t.Errorf("did not expect error with IATLeeway disabled, got %v", err)
+	}
+}
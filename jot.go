@@ -1,6 +1,7 @@
 package jot
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
@@ -11,14 +12,55 @@ import (
 
 // Auth is the type used to instantiate this package.
 type Auth struct {
-	Issuer        string        // Who issues the token, e.g. company.com.
-	Audience      string        // Who is the token for, e.g. company.com.
-	Secret        string        // A strong secret, used to sign the tokens.
-	TokenExpiry   time.Duration // When does the token expire, e.g. time.Minute * 15.
-	RefreshExpiry time.Duration // When does the refresh token expire, e.g. time.Hour * 24.
-	CookieDomain  string        // The domain, for refresh cookies.
-	CookiePath    string        // The path, for refresh cookies.
-	CookieName    string        // The name of the refresh token cookie.
+	Issuer        string            // Who issues the token, e.g. company.com.
+	Audience      string            // Who is the token for, e.g. company.com.
+	Secret        string            // A strong secret, used to sign the tokens, when no KeyProvider is set.
+	SigningMethod jwt.SigningMethod // Algorithm used to sign tokens. Defaults to jwt.SigningMethodHS256 if nil.
+	KeyProvider   KeyProvider       // Optional source of signing/verification keys, for asymmetric algorithms.
+	TokenExpiry   time.Duration     // When does the token expire, e.g. time.Minute * 15.
+	RefreshExpiry time.Duration     // When does the refresh token expire, e.g. time.Hour * 24.
+	CookieDomain  string            // The domain, for refresh cookies.
+	CookiePath    string            // The path, for refresh cookies.
+	CookieName    string            // The name of the refresh token cookie.
+	RefreshStore  RefreshStore      // Optional store used to rotate refresh tokens and detect reuse.
+	MaxRefreshes  int               // Maximum number of times a refresh token may be refreshed. 0 = unlimited.
+	ClaimsBuilder ClaimsBuilder     // Optional hook for adding custom claims to an access token before signing.
+	IATLeeway     time.Duration     // Max allowed drift between iat and the server clock. 0 = disabled.
+
+	// AccessTokenCookieName is the cookie Middleware reads from when no Authorization
+	// header is present. Defaults to "__Host-access_token".
+	AccessTokenCookieName string
+
+	// OnError, if set, lets Middleware customize the response written on a failed
+	// verification instead of the default plain-text 401.
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// signingMethod returns the configured SigningMethod, falling back to HS256 so existing
+// callers who never set the field keep working unchanged.
+func (j *Auth) signingMethod() jwt.SigningMethod {
+	if j.SigningMethod != nil {
+		return j.SigningMethod
+	}
+	return jwt.SigningMethodHS256
+}
+
+// signingKey returns the key to use when signing a token, and the "kid" (if any) to set
+// on the JOSE header, taking KeyProvider into account when one is configured.
+func (j *Auth) signingKey() (interface{}, string, error) {
+	if j.KeyProvider != nil {
+		return j.KeyProvider.SigningKey()
+	}
+	return []byte(j.Secret), "", nil
+}
+
+// verificationKey returns the key to use when verifying a token bearing the given kid,
+// taking KeyProvider into account when one is configured.
+func (j *Auth) verificationKey(kid string) (interface{}, error) {
+	if j.KeyProvider != nil {
+		return j.KeyProvider.VerificationKey(kid)
+	}
+	return []byte(j.Secret), nil
 }
 
 // User is a generic type used to hold the minimal amount of data we require in order to issue tokens.
@@ -34,22 +76,18 @@ type TokenPairs struct {
 	RefreshToken string `json:"refresh_token"`
 }
 
-// Claims is the type used to describe the claims in a given token.
-type Claims struct {
-	jwt.RegisteredClaims
-}
-
 // New returns an instance of Auth, with sensible defaults where possible. Naturally,
 // any of defaults can be overridden, if necessary.
 func New(d string) Auth {
 	return Auth{
-		Issuer:        d,
-		Audience:      d,
-		TokenExpiry:   time.Minute * 15,
-		RefreshExpiry: time.Hour * 24,
-		CookieName:    "__Host-refresh_token",
-		CookiePath:    "/",
-		CookieDomain:  d,
+		Issuer:                d,
+		Audience:              d,
+		TokenExpiry:           time.Minute * 15,
+		RefreshExpiry:         time.Hour * 24,
+		CookieName:            "__Host-refresh_token",
+		CookiePath:            "/",
+		CookieDomain:          d,
+		AccessTokenCookieName: "__Host-access_token",
 	}
 }
 
@@ -63,7 +101,7 @@ func (j *Auth) GetTokenFromHeaderAndVerify(w http.ResponseWriter, r *http.Reques
 
 	// Sanity check.
 	if authHeader == "" {
-		return "", nil, errors.New("no auth header")
+		return "", nil, ErrNoAuthHeader
 	}
 
 	// Split the header up on spaces.
@@ -80,46 +118,98 @@ func (j *Auth) GetTokenFromHeaderAndVerify(w http.ResponseWriter, r *http.Reques
 	// Get the actual token.
 	token := headerParts[1]
 
+	// Parse the token, and check its signature, issuer, and audience.
+	claims, err := j.verifyToken(token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// If we get this far, the token is valid, so we return it, along with the claims.
+	return token, claims, nil
+}
+
+// verifyToken parses tokenString, checks its signature against our configured signing
+// method and key, and makes sure it was issued by us and for us, before returning the
+// claims it carries.
+func (j *Auth) verifyToken(tokenString string) (*Claims, error) {
 	// Declare an empty Claims variable.
 	claims := &Claims{}
 
 	// Parse the token with our claims (we read into claims), using our secret (from the receiver).
-	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		// validate the signing algorithm is what we expect
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// validate the signing algorithm is the one we're configured to accept
+		if token.Method.Alg() != j.signingMethod().Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(j.Secret), nil
+
+		// a kid header lets a KeyProvider pick the right verification key out of a set
+		kid, _ := token.Header["kid"].(string)
+		return j.verificationKey(kid)
 	})
 
 	// Check for errors. Note that this catches expired tokens as well.
 	if err != nil {
-		// return an easy to spot error if the token is expired
-		if strings.HasPrefix(err.Error(), "token is expired by") {
-			return "", nil, errors.New("expired token")
+		// return a sentinel error if the token is expired, so callers can errors.Is it
+		// instead of matching on err.Error(), which isn't stable across golang-jwt versions
+		if isExpiredError(err) {
+			return nil, ErrExpiredToken
 		}
-		return "", nil, err
+		return nil, err
 	}
 
 	// Make sure we issued this token.
 	if claims.Issuer != j.Issuer {
 		// we did not issue this token
-		return "", nil, errors.New("incorrect issuer")
+		return nil, ErrInvalidIssuer
 	}
 
-	// If we get this far, the token is valid, so we return it, along with the claims.
-	return token, claims, nil
+	// Make sure this token is meant for us.
+	if j.Audience != "" {
+		audienceOK := false
+		for _, aud := range claims.Audience {
+			if aud == j.Audience {
+				audienceOK = true
+				break
+			}
+		}
+		if !audienceOK {
+			return nil, ErrInvalidAudience
+		}
+	}
+
+	// For machine-to-machine tokens, bind each token to a short window around when it
+	// was issued, so a stolen long-lived token can't be replayed indefinitely.
+	if j.IATLeeway > 0 {
+		if claims.IssuedAt == nil {
+			return nil, ErrTokenNotFresh
+		}
+		age := time.Since(claims.IssuedAt.Time)
+		if age < 0 {
+			age = -age
+		}
+		if age > j.IATLeeway {
+			return nil, ErrTokenNotFresh
+		}
+	}
+
+	return claims, nil
 }
 
-// GenerateTokenPair takes a user of type jot.User and attempts to generate a pair of tokens for that user
-// (jwt and refresh tokens).
-func (j *Auth) GenerateTokenPair(user *User) (TokenPairs, error) {
-	// Create token.
-	token := jwt.New(jwt.SigningMethodHS256)
+// newAccessToken builds and signs an access token for user, using the receiver's
+// configured signing method, key, issuer, and audience, expiring after ttl. name is
+// used verbatim as the "name" claim, so a caller rebuilding a token (e.g. Auth.Refresh,
+// which only has a user ID to go on) can pass through a name it got from elsewhere
+// instead of recomputing it from a (possibly incomplete) User. If scopes is non-empty,
+// it's space-joined into a "scope" claim per RFC 8693.
+func (j *Auth) newAccessToken(user *User, name string, scopes []string, ttl time.Duration) (string, error) {
+	signingKey, kid, err := j.signingKey()
+	if err != nil {
+		return "", err
+	}
 
 	// Set claims.
-	claims := token.Claims.(jwt.MapClaims)
-	claims["name"] = fmt.Sprintf("%s %s", user.FirstName, user.LastName)
+	claims := jwt.MapClaims{}
+	claims["name"] = name
 	claims["sub"] = fmt.Sprint(user.ID)
 	claims["aud"] = j.Audience
 	claims["iss"] = j.Issuer
@@ -127,29 +217,68 @@ func (j *Auth) GenerateTokenPair(user *User) (TokenPairs, error) {
 	claims["typ"] = "JWT"
 
 	// Set expiry; should be short!
-	claims["exp"] = time.Now().UTC().Add(j.TokenExpiry).Unix()
+	claims["exp"] = time.Now().UTC().Add(ttl).Unix()
+
+	if len(scopes) > 0 {
+		claims["scope"] = strings.Join(scopes, " ")
+	}
+
+	// Let the caller add or override claims (roles, scopes, tenant IDs, ...) before we sign.
+	if j.ClaimsBuilder != nil {
+		extra, err := j.ClaimsBuilder(user)
+		if err != nil {
+			return "", err
+		}
+		for k, v := range extra {
+			claims[k] = v
+		}
+	}
+
+	// Create token.
+	token := jwt.NewWithClaims(j.signingMethod(), claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
 
 	// Create signed token.
-	signedAccessToken, err := token.SignedString([]byte(j.Secret))
+	return token.SignedString(signingKey)
+}
+
+// GenerateTokenPair takes a user of type jot.User and attempts to generate a pair of tokens for that user
+// (jwt and refresh tokens). scopes, if given, are space-joined into a "scope" claim per
+// RFC 8693, for callers authorizing with Auth.RequireScope.
+func (j *Auth) GenerateTokenPair(user *User, scopes ...string) (TokenPairs, error) {
+	// Name and scope are embedded in the refresh token too, so Auth.Refresh can reissue
+	// an identical access token later without anything more than the refresh token itself.
+	name := fmt.Sprintf("%s %s", user.FirstName, user.LastName)
+	scope := strings.Join(scopes, " ")
+
+	// Create signed access token.
+	signedAccessToken, err := j.newAccessToken(user, name, scopes, j.TokenExpiry)
 	if err != nil {
 		return TokenPairs{}, err
 	}
 
-	// Create refresh token and set claims (just subject and expiry).
-	refreshToken := jwt.New(jwt.SigningMethodHS256)
-	refreshTokenClaims := refreshToken.Claims.(jwt.MapClaims)
-	refreshTokenClaims["sub"] = fmt.Sprint(user.ID)
-	refreshTokenClaims["iat"] = time.Now().UTC().Unix()
-
-	// Set expiry; must be longer than JWT token expiry!
-	refreshTokenClaims["exp"] = time.Now().UTC().Add(j.RefreshExpiry).Unix()
+	// Every refresh token gets its own jti, so a RefreshStore can track and rotate it.
+	userID := fmt.Sprint(user.ID)
+	jti, err := newJTI()
+	if err != nil {
+		return TokenPairs{}, err
+	}
 
-	// Create signed refresh token.
-	signedRefreshToken, err := refreshToken.SignedString([]byte(j.Secret))
+	// Create refresh token and set claims (subject, jti, refresh count, name, and scope).
+	signedRefreshToken, exp, err := j.newRefreshToken(userID, jti, 0, name, scope)
 	if err != nil {
 		return TokenPairs{}, err
 	}
 
+	// Record the refresh token against its jti so it can be rotated later.
+	if j.RefreshStore != nil {
+		if err := j.RefreshStore.Save(context.Background(), jti, userID, exp); err != nil {
+			return TokenPairs{}, err
+		}
+	}
+
 	// Create token pairs and populate with signed tokens.
 	var tokenPairs = TokenPairs{
 		Token:        signedAccessToken,
@@ -0,0 +1,126 @@
+package jot
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// KeyProvider supplies the keys jot needs to sign and verify tokens when a deployment
+// requires asymmetric algorithms (RS256, ES256, EdDSA, ...) instead of a shared secret.
+// Implementations typically wrap a key rotation scheme, keeping older keys around for
+// VerificationKey so tokens signed before a rotation can still be verified.
+type KeyProvider interface {
+	// SigningKey returns the key that should be used to sign new tokens - a private key
+	// for asymmetric algorithms, or the shared secret for HMAC - along with a "kid"
+	// identifying it. The kid is set on the JOSE header so VerificationKey can find the
+	// matching key later.
+	SigningKey() (interface{}, string, error)
+
+	// VerificationKey returns the key that corresponds to kid - a public key for
+	// asymmetric algorithms, or the shared secret for HMAC.
+	VerificationKey(kid string) (interface{}, error)
+}
+
+// JWKSKeyProvider is an optional extension to KeyProvider. A KeyProvider that implements
+// it can be published as a JWKS document via Auth.JWKSHandler, so resource servers can
+// pull verification material over HTTP instead of sharing the signing key out of band.
+type JWKSKeyProvider interface {
+	KeyProvider
+
+	// PublicKeys returns every currently valid public key, keyed by kid.
+	PublicKeys() (map[string]interface{}, error)
+}
+
+// JWKSHandler returns an http.Handler that serves the KeyProvider's current public keys
+// as a JWKS (RFC 7517) document. It responds 404 if no KeyProvider is configured, and 500
+// if the configured KeyProvider doesn't implement JWKSKeyProvider.
+func (j *Auth) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if j.KeyProvider == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		jwksProvider, ok := j.KeyProvider.(JWKSKeyProvider)
+		if !ok {
+			http.Error(w, "jot: key provider does not support JWKS enumeration", http.StatusInternalServerError)
+			return
+		}
+
+		keys, err := jwksProvider.PublicKeys()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		alg := j.signingMethod().Alg()
+		jwks := make([]map[string]interface{}, 0, len(keys))
+		for kid, key := range keys {
+			jwk, err := publicKeyToJWK(kid, alg, key)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			jwks = append(jwks, jwk)
+		}
+
+		w.Header().Set("Content-Type", "application/jwk-set+json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": jwks})
+	})
+}
+
+// publicKeyToJWK converts a public key to its JWK representation, per RFC 7518.
+func publicKeyToJWK(kid, alg string, key interface{}) (map[string]interface{}, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]interface{}{
+			"kty": "RSA",
+			"kid": kid,
+			"alg": alg,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(bigEndianBytes(k.E)),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return map[string]interface{}{
+			"kty": "EC",
+			"kid": kid,
+			"alg": alg,
+			"use": "sig",
+			"crv": k.Curve.Params().Name,
+			"x":   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			"y":   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return map[string]interface{}{
+			"kty": "OKP",
+			"kid": kid,
+			"alg": alg,
+			"use": "sig",
+			"crv": "Ed25519",
+			"x":   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	default:
+		return nil, fmt.Errorf("jot: unsupported public key type %T", key)
+	}
+}
+
+// bigEndianBytes encodes a small, non-negative int (an RSA public exponent) as the
+// minimal big-endian byte slice expected by the JWK "e" member.
+func bigEndianBytes(n int) []byte {
+	if n == 0 {
+		return []byte{0}
+	}
+	var b []byte
+	for n > 0 {
+		b = append([]byte{byte(n & 0xff)}, b...)
+		n >>= 8
+	}
+	return b
+}
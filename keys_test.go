@@ -0,0 +1,91 @@
+package jot
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// stubJWKSKeyProvider is a minimal JWKSKeyProvider backed by a single Ed25519 key pair,
+// used only to exercise Auth.JWKSHandler.
+type stubJWKSKeyProvider struct {
+	public ed25519.PublicKey
+	kid    string
+}
+
+func (s stubJWKSKeyProvider) SigningKey() (interface{}, string, error) {
+	return nil, s.kid, nil
+}
+
+func (s stubJWKSKeyProvider) VerificationKey(_ string) (interface{}, error) {
+	return s.public, nil
+}
+
+func (s stubJWKSKeyProvider) PublicKeys() (map[string]interface{}, error) {
+	return map[string]interface{}{s.kid: s.public}, nil
+}
+
+// stubKeyProvider is a KeyProvider that does not implement JWKSKeyProvider.
+type stubKeyProvider struct{}
+
+func (stubKeyProvider) SigningKey() (interface{}, string, error)      { return nil, "", nil }
+func (stubKeyProvider) VerificationKey(_ string) (interface{}, error) { return nil, nil }
+
+func TestJWKSHandlerNoKeyProvider(t *testing.T) {
+	jwksApp := app
+	jwksApp.KeyProvider = nil
+
+	rr := httptest.NewRecorder()
+	jwksApp.JWKSHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("expected %d with no KeyProvider configured, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestJWKSHandlerKeyProviderNotJWKSCapable(t *testing.T) {
+	jwksApp := app
+	jwksApp.KeyProvider = stubKeyProvider{}
+
+	rr := httptest.NewRecorder()
+	jwksApp.JWKSHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("expected %d when KeyProvider doesn't implement JWKSKeyProvider, got %d", http.StatusInternalServerError, rr.Code)
+	}
+}
+
+func TestJWKSHandlerServesKeys(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("did not expect error generating key, got %v", err)
+	}
+
+	jwksApp := app
+	jwksApp.KeyProvider = stubJWKSKeyProvider{public: pub, kid: "kid-1"}
+
+	rr := httptest.NewRecorder()
+	jwksApp.JWKSHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+	}
+
+	var body struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("did not expect error unmarshalling response, got %v", err)
+	}
+	if len(body.Keys) != 1 {
+		t.Fatalf("expected 1 key in JWKS document, got %d", len(body.Keys))
+	}
+	if body.Keys[0]["kid"] != "kid-1" {
+		t.Errorf("expected kid %q, got %v", "kid-1", body.Keys[0]["kid"])
+	}
+	if body.Keys[0]["kty"] != "OKP" {
+		t.Errorf("expected kty %q for an Ed25519 key, got %v", "OKP", body.Keys[0]["kty"])
+	}
+}
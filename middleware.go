@@ -0,0 +1,121 @@
+package jot
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// contextKey is an unexported type so the keys Middleware stashes values under can't
+// collide with keys set by other packages using the same context.
+type contextKey int
+
+const (
+	claimsContextKey contextKey = iota
+	subjectContextKey
+)
+
+// ClaimsFromContext returns the Claims that Middleware verified and stored on ctx.
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(*Claims)
+	return claims, ok
+}
+
+// SubjectFromContext returns the verified token's subject (the user ID) that Middleware
+// stored on ctx.
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	subject, ok := ctx.Value(subjectContextKey).(string)
+	return subject, ok
+}
+
+// Middleware verifies the access token on an incoming request - from the Authorization
+// header if present, otherwise falling back to the AccessTokenCookieName cookie - and
+// injects the resulting Claims and subject into the request context for downstream
+// handlers to read with ClaimsFromContext and SubjectFromContext. Its signature is
+// exactly the func(http.Handler) http.Handler that chi's Router.Use and gorilla/mux's
+// Router.Use expect, so it can be registered directly with either.
+func (j *Auth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Authorization")
+
+		token := bearerToken(r)
+		if token == "" {
+			token = cookieToken(r, j.accessTokenCookieName())
+		}
+		if token == "" {
+			j.onError(w, r, ErrNoAuthHeader)
+			return
+		}
+
+		claims, err := j.verifyToken(token)
+		if err != nil {
+			j.onError(w, r, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), claimsContextKey, claims)
+		ctx = context.WithValue(ctx, subjectContextKey, claims.Subject)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// bearerToken extracts the token from a "Bearer <token>" Authorization header, or
+// returns "" if the header is absent or malformed.
+func bearerToken(r *http.Request) string {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return ""
+	}
+
+	headerParts := strings.Split(authHeader, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" {
+		return ""
+	}
+	return headerParts[1]
+}
+
+// cookieToken extracts the token from cookie name, or returns "" if it isn't set.
+func cookieToken(r *http.Request, name string) string {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// accessTokenCookieName returns the configured AccessTokenCookieName, falling back to
+// "__Host-access_token" so existing callers who never set the field keep working.
+func (j *Auth) accessTokenCookieName() string {
+	if j.AccessTokenCookieName != "" {
+		return j.AccessTokenCookieName
+	}
+	return "__Host-access_token"
+}
+
+// onError reports a failed verification to the caller's OnError hook, or, if none is
+// set, writes a plain 401 response.
+func (j *Auth) onError(w http.ResponseWriter, r *http.Request, err error) {
+	if j.OnError != nil {
+		j.OnError(w, r, err)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusUnauthorized)
+}
+
+// SetAccessTokenCookie sets an http-only, secure cookie carrying the access token, for
+// servers that want to operate in cookie-only mode instead of the Authorization header.
+// It mirrors GetRefreshCookie.
+func (j *Auth) SetAccessTokenCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     j.accessTokenCookieName(),
+		Path:     j.CookiePath,
+		Value:    token,
+		Expires:  time.Now().Add(j.TokenExpiry),
+		MaxAge:   int(j.TokenExpiry.Seconds()),
+		SameSite: http.SameSiteStrictMode,
+		Domain:   j.CookieDomain,
+		HttpOnly: true,
+		Secure:   true,
+	})
+}
@@ -0,0 +1,88 @@
+package jot
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthMiddlewareCookieFallback(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	tokens, err := app.GenerateTokenPair(&testUser)
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	var gotSubject string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject, ok := SubjectFromContext(r.Context())
+		if !ok {
+			t.Error("expected subject in context, but none found")
+		}
+		gotSubject = subject
+		w.WriteHeader(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: app.accessTokenCookieName(), Value: tokens.Token})
+
+	rr := httptest.NewRecorder()
+	app.Middleware(next).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d", http.StatusOK, rr.Code)
+	}
+	if gotSubject != "1" {
+		t.Errorf("expected subject %q from cookie-authenticated request, got %q", "1", gotSubject)
+	}
+}
+
+func TestAuthMiddlewareNoTokenAnywhere(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called when no token is present")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	app.Middleware(next).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
+
+func TestAuthMiddlewareRejectsWrongAudience(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	otherAudienceApp := app
+	otherAudienceApp.Audience = "other.org"
+
+	tokens, err := otherAudienceApp.GenerateTokenPair(&testUser)
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called when the token's audience doesn't match")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", tokens.Token))
+
+	rr := httptest.NewRecorder()
+	app.Middleware(next).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected %d for a token with the wrong audience, got %d", http.StatusUnauthorized, rr.Code)
+	}
+}
@@ -0,0 +1,264 @@
+package jot
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// refreshTokenType is the "typ" claim value set on every refresh token, so Auth.Refresh
+// can tell a refresh token from an ordinary access token on its own - independent of
+// whether a RefreshStore is configured to catch the mismatch via jti lookup.
+const refreshTokenType = "refresh"
+
+// Sentinel errors returned by Auth.Refresh and the built-in MemoryRefreshStore.
+var (
+	// ErrNotRefreshToken is returned when the token passed to Auth.Refresh doesn't carry
+	// the refresh token's typ and jti claims - most likely because an access token was
+	// presented in its place.
+	ErrNotRefreshToken = errors.New("jot: token is not a refresh token")
+
+	// ErrMaxRefreshesExceeded is returned when a refresh token has been refreshed
+	// MaxRefreshes times already, and Auth.MaxRefreshes is non-zero.
+	ErrMaxRefreshesExceeded = errors.New("jot: refresh token has exceeded its maximum number of refreshes")
+
+	// ErrRefreshTokenReused is returned when a refresh token whose jti was already
+	// consumed is presented again. Every refresh token for the user is revoked.
+	ErrRefreshTokenReused = errors.New("jot: refresh token reuse detected, all sessions for user revoked")
+
+	// ErrRefreshTokenUnknown is returned by the default RefreshStore when a jti it
+	// doesn't recognise is rotated.
+	ErrRefreshTokenUnknown = errors.New("jot: refresh token not recognised by store")
+
+	// ErrRefreshTokenRevoked is returned when a refresh token's jti has been revoked.
+	ErrRefreshTokenRevoked = errors.New("jot: refresh token has been revoked")
+)
+
+// RefreshStore tracks issued refresh tokens by their jti, so they can be rotated on use
+// and so reuse of an already-consumed refresh token can be detected (the OAuth "refresh
+// token reuse detection" pattern).
+type RefreshStore interface {
+	// Save records a freshly issued refresh token's jti, owning user, and expiry.
+	Save(ctx context.Context, jti, userID string, exp time.Time) error
+
+	// Rotate atomically consumes oldJTI and records newJTI in its place. reused is true
+	// if oldJTI had already been consumed by a previous Rotate call, signalling that the
+	// refresh token has been replayed.
+	Rotate(ctx context.Context, oldJTI, newJTI string, exp time.Time) (reused bool, err error)
+
+	// Revoke marks jti as no longer usable.
+	Revoke(ctx context.Context, jti string) error
+
+	// RevokeAllForUser marks every jti belonging to userID as no longer usable.
+	RevokeAllForUser(ctx context.Context, userID string) error
+}
+
+// refreshClaims describes the claims embedded in a refresh token: just enough to
+// identify the owning user, the token itself (jti), how many times it has already been
+// refreshed, and the display name/scope the paired access token was originally issued
+// with, so both survive rotation.
+type refreshClaims struct {
+	TokenType    string `json:"typ,omitempty"`
+	RefreshCount int    `json:"refresh_count,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// newJTI returns a random, URL-safe token identifier suitable for use as a jti claim.
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// newRefreshToken builds and signs a refresh token for userID, carrying jti,
+// refreshCount, name, and scope as claims, and returns the signed token along with its
+// expiry. name and scope are carried forward on every rotation so Auth.Refresh can
+// reissue an access token identical to the one the refresh token was paired with.
+func (j *Auth) newRefreshToken(userID, jti string, refreshCount int, name, scope string) (string, time.Time, error) {
+	signingKey, kid, err := j.signingKey()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	now := time.Now().UTC()
+	exp := now.Add(j.RefreshExpiry)
+
+	refreshToken := jwt.NewWithClaims(j.signingMethod(), &refreshClaims{
+		TokenType:    refreshTokenType,
+		RefreshCount: refreshCount,
+		Name:         name,
+		Scope:        scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   userID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+		},
+	})
+	if kid != "" {
+		refreshToken.Header["kid"] = kid
+	}
+
+	signed, err := refreshToken.SignedString(signingKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, exp, nil
+}
+
+// Refresh verifies refreshToken, rotates it via RefreshStore (if configured), and
+// returns a new token pair. If a refresh token whose jti was already consumed is
+// presented, every refresh token belonging to its user is revoked and
+// ErrRefreshTokenReused is returned.
+func (j *Auth) Refresh(ctx context.Context, refreshToken string) (TokenPairs, error) {
+	claims := &refreshClaims{}
+
+	_, err := jwt.ParseWithClaims(refreshToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != j.signingMethod().Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return j.verificationKey(kid)
+	})
+	if err != nil {
+		return TokenPairs{}, err
+	}
+
+	// Reject anything that isn't actually a refresh token - e.g. an ordinary access
+	// token, which would otherwise sail through and mint itself a full-lifetime,
+	// indefinitely renewable session. This check stands on its own, independent of
+	// whether a RefreshStore is configured to catch it via jti lookup.
+	if claims.TokenType != refreshTokenType || claims.ID == "" {
+		return TokenPairs{}, ErrNotRefreshToken
+	}
+
+	if j.MaxRefreshes > 0 && claims.RefreshCount >= j.MaxRefreshes {
+		return TokenPairs{}, ErrMaxRefreshesExceeded
+	}
+
+	userID := claims.Subject
+
+	newJTIValue, err := newJTI()
+	if err != nil {
+		return TokenPairs{}, err
+	}
+
+	if j.RefreshStore != nil {
+		reused, err := j.RefreshStore.Rotate(ctx, claims.ID, newJTIValue, time.Now().UTC().Add(j.RefreshExpiry))
+		if err != nil {
+			return TokenPairs{}, err
+		}
+		if reused {
+			if revokeErr := j.RefreshStore.RevokeAllForUser(ctx, userID); revokeErr != nil {
+				return TokenPairs{}, fmt.Errorf("%w (and revoking sessions for the user also failed: %v)", ErrRefreshTokenReused, revokeErr)
+			}
+			return TokenPairs{}, ErrRefreshTokenReused
+		}
+	}
+
+	userIDInt, err := strconv.Atoi(userID)
+	if err != nil {
+		return TokenPairs{}, fmt.Errorf("jot: invalid subject in refresh token: %w", err)
+	}
+
+	// Reissue the access token with the same name and scopes it originally carried;
+	// both are threaded through refreshClaims on every rotation.
+	signedAccessToken, err := j.newAccessToken(&User{ID: userIDInt}, claims.Name, strings.Fields(claims.Scope), j.TokenExpiry)
+	if err != nil {
+		return TokenPairs{}, err
+	}
+
+	signedRefreshToken, _, err := j.newRefreshToken(userID, newJTIValue, claims.RefreshCount+1, claims.Name, claims.Scope)
+	if err != nil {
+		return TokenPairs{}, err
+	}
+
+	return TokenPairs{Token: signedAccessToken, RefreshToken: signedRefreshToken}, nil
+}
+
+// refreshRecord is the state MemoryRefreshStore keeps for a single jti.
+type refreshRecord struct {
+	userID   string
+	exp      time.Time
+	consumed bool
+	revoked  bool
+}
+
+// MemoryRefreshStore is an in-memory RefreshStore. It's suitable for single-instance
+// deployments and tests; state does not survive a restart. Refresh rotation is opt-in:
+// construct one with NewMemoryRefreshStore and assign it to Auth.RefreshStore.
+type MemoryRefreshStore struct {
+	mu      sync.Mutex
+	records map[string]*refreshRecord
+}
+
+// NewMemoryRefreshStore returns a ready to use MemoryRefreshStore.
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{records: make(map[string]*refreshRecord)}
+}
+
+// Save implements RefreshStore.
+func (s *MemoryRefreshStore) Save(_ context.Context, jti, userID string, exp time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[jti] = &refreshRecord{userID: userID, exp: exp}
+	return nil
+}
+
+// Rotate implements RefreshStore.
+func (s *MemoryRefreshStore) Rotate(_ context.Context, oldJTI, newJTI string, exp time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[oldJTI]
+	if !ok {
+		return false, ErrRefreshTokenUnknown
+	}
+	if record.revoked {
+		return false, ErrRefreshTokenRevoked
+	}
+	if record.consumed {
+		return true, nil
+	}
+
+	record.consumed = true
+	s.records[newJTI] = &refreshRecord{userID: record.userID, exp: exp}
+	return false, nil
+}
+
+// Revoke implements RefreshStore.
+func (s *MemoryRefreshStore) Revoke(_ context.Context, jti string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[jti]; ok {
+		record.revoked = true
+	}
+	return nil
+}
+
+// RevokeAllForUser implements RefreshStore.
+func (s *MemoryRefreshStore) RevokeAllForUser(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range s.records {
+		if record.userID == userID {
+			record.revoked = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package jot
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAuthRefresh(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	refreshApp := app
+	refreshApp.RefreshStore = NewMemoryRefreshStore()
+
+	tokens, err := refreshApp.GenerateTokenPair(&testUser, "read:widgets")
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	rotated, err := refreshApp.Refresh(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("did not expect error on first refresh, got %v", err)
+	}
+
+	claims, err := refreshApp.verifyToken(rotated.Token)
+	if err != nil {
+		t.Fatalf("did not expect error verifying rotated access token, got %v", err)
+	}
+	if claims.Name != "Admin User" {
+		t.Errorf("expected name to survive rotation as %q, got %q", "Admin User", claims.Name)
+	}
+	if !claims.HasScope("read:widgets") {
+		t.Error("expected scope to survive rotation, but it did not")
+	}
+
+	// Replaying the already-rotated refresh token should be detected as reuse and revoke
+	// every refresh token belonging to the user, including the one just issued above.
+	_, err = refreshApp.Refresh(context.Background(), tokens.RefreshToken)
+	if !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("expected ErrRefreshTokenReused on replay, got %v", err)
+	}
+
+	_, err = refreshApp.Refresh(context.Background(), rotated.RefreshToken)
+	if !errors.Is(err, ErrRefreshTokenRevoked) {
+		t.Errorf("expected ErrRefreshTokenRevoked after reuse revoked all sessions, got %v", err)
+	}
+}
+
+func TestAuthRefreshMaxRefreshesExceeded(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	refreshApp := app
+	refreshApp.RefreshStore = NewMemoryRefreshStore()
+	refreshApp.MaxRefreshes = 1
+
+	tokens, err := refreshApp.GenerateTokenPair(&testUser)
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	// The first refresh brings RefreshCount to 1, which is still within the cap.
+	rotated, err := refreshApp.Refresh(context.Background(), tokens.RefreshToken)
+	if err != nil {
+		t.Fatalf("did not expect error on first refresh, got %v", err)
+	}
+
+	// The second refresh would bring RefreshCount to 2, exceeding MaxRefreshes.
+	_, err = refreshApp.Refresh(context.Background(), rotated.RefreshToken)
+	if !errors.Is(err, ErrMaxRefreshesExceeded) {
+		t.Errorf("expected ErrMaxRefreshesExceeded once MaxRefreshes is exceeded, got %v", err)
+	}
+}
+
+func TestAuthRefreshRejectsAccessToken(t *testing.T) {
+	testUser := User{
+		ID:        1,
+		FirstName: "Admin",
+		LastName:  "User",
+	}
+
+	tokens, err := app.GenerateTokenPair(&testUser)
+	if err != nil {
+		t.Fatalf("did not expect error generating token pair, got %v", err)
+	}
+
+	// An access token has no typ/jti claims identifying it as a refresh token, so it must
+	// be rejected even though it's validly signed and otherwise well-formed.
+	_, err = app.Refresh(context.Background(), tokens.Token)
+	if !errors.Is(err, ErrNotRefreshToken) {
+		t.Errorf("expected ErrNotRefreshToken when refreshing with an access token, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package jot
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// GenerateScopedToken mints a single, short-lived access token for user carrying
+// scopes, with no refresh token partner. Unlike GenerateTokenPair, it's suited to
+// capability-style use cases - download links, signed upload URLs - that shouldn't be
+// renewable, so there's no refresh machinery to issue or store.
+func (j *Auth) GenerateScopedToken(user *User, scopes []string, ttl time.Duration) (string, error) {
+	name := fmt.Sprintf("%s %s", user.FirstName, user.LastName)
+	return j.newAccessToken(user, name, scopes, ttl)
+}
+
+// RequireScope returns middleware that responds 403 if the Claims that Middleware
+// injected into the request context lack any of scopes - i.e. the token must carry
+// every scope passed in, not just one of them. It must run after Middleware, since
+// that's what populates the context it reads from.
+func (j *Auth) RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				j.onError(w, r, ErrNoAuthHeader)
+				return
+			}
+
+			for _, scope := range scopes {
+				if !claims.HasScope(scope) {
+					http.Error(w, "forbidden", http.StatusForbidden)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}